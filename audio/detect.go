@@ -0,0 +1,87 @@
+// Package audio picks the right AudioDecoder for a Spotify CDN stream, sniffing the container to
+// tell an Ogg/Vorbis track from an Ogg/Opus one.
+package audio
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	librespot "go-librespot"
+	"go-librespot/opus"
+	"go-librespot/vorbis"
+)
+
+const (
+	oggPageHeaderLen = 27
+	sniffBufferSize  = 4096
+)
+
+// Format identifies the codec carried by an Ogg-framed Spotify stream.
+type Format string
+
+const (
+	FormatVorbis Format = "vorbis"
+	FormatOpus   Format = "opus"
+)
+
+// DetectFormat reads the first Ogg page of r far enough to inspect the magic number of its first
+// packet (`\x01vorbis` or `OpusHead`, as fq's ogg format does), then rewinds r back to the start.
+func DetectFormat(r librespot.SizedReadAtSeeker) (Format, error) {
+	buf := make([]byte, sniffBufferSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", fmt.Errorf("audio: failed reading stream header: %w", err)
+	}
+	buf = buf[:n]
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("audio: failed rewinding stream: %w", err)
+	}
+
+	if len(buf) < oggPageHeaderLen || string(buf[0:4]) != "OggS" {
+		return "", errors.New("audio: not a valid Ogg bitstream")
+	}
+
+	nsegs := int(buf[26])
+	payloadStart := oggPageHeaderLen + nsegs
+	if len(buf) < payloadStart {
+		return "", errors.New("audio: truncated Ogg page header")
+	}
+
+	payloadLen := 0
+	for _, s := range buf[oggPageHeaderLen:payloadStart] {
+		payloadLen += int(s)
+	}
+	if payloadStart+payloadLen > len(buf) {
+		payloadLen = len(buf) - payloadStart
+	}
+	payload := buf[payloadStart : payloadStart+payloadLen]
+
+	switch {
+	case bytes.HasPrefix(payload, []byte("\x01vorbis")):
+		return FormatVorbis, nil
+	case bytes.HasPrefix(payload, []byte("OpusHead")):
+		return FormatOpus, nil
+	default:
+		return "", errors.New("audio: unrecognised codec in Ogg stream")
+	}
+}
+
+// NewDecoder sniffs r and constructs the matching AudioDecoder (Vorbis or Opus) for it.
+func NewDecoder(r librespot.SizedReadAtSeeker, duration int32, gain float32) (librespot.AudioDecoder, error) {
+	format, err := DetectFormat(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatVorbis:
+		return vorbis.New(r, duration, gain)
+	case FormatOpus:
+		return opus.New(r, duration, gain)
+	default:
+		return nil, fmt.Errorf("audio: unsupported format %q", format)
+	}
+}
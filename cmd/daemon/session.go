@@ -72,8 +72,8 @@ func (s *Session) handleDealerMessage(msg dealer.Message) error {
 						SupportsLogout:             true,
 						IsObservable:               true,
 						VolumeSteps:                VolumeSteps,
-						SupportedTypes:             []string{"audio/track"}, // TODO: support episodes
-						CommandAcks:                true,                    // TODO: actually send ack
+						SupportedTypes:             []string{"audio/track", "audio/episode"},
+						CommandAcks:                true, // TODO: actually send ack
 						SupportsRename:             false,
 						Hidden:                     false,
 						DisableVolume:              false,
@@ -0,0 +1,29 @@
+package librespot
+
+// AudioDecoder is implemented by every codec-specific decoder (Vorbis, Opus, ...) able to turn a
+// compressed Spotify audio stream into interleaved float32 PCM samples.
+type AudioDecoder interface {
+	// Read decodes PCM samples into p and returns the number of samples written.
+	Read(p []float32) (n int, err error)
+
+	// SetPositionMs seeks the decoder to the given position, in milliseconds.
+	SetPositionMs(pos int64) error
+
+	// PositionMs returns the current decoding position, in milliseconds.
+	PositionMs() int64
+
+	// Info returns basic information about the decoded audio stream.
+	Info() AudioInfo
+
+	// Close stops the decoder and releases any resources it holds.
+	Close()
+}
+
+// AudioInfo represents basic information about the audio content being decoded, common to all
+// AudioDecoder implementations.
+type AudioInfo struct {
+	Channels   int32
+	SampleRate int32
+	Comments   []string
+	Vendor     string
+}
@@ -0,0 +1,294 @@
+package opus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	librespot "go-librespot"
+
+	"github.com/hraban/opus"
+	"github.com/xlab/vorbis-go/vorbis"
+)
+
+const (
+	// DataChunkSize represents the amount of data read from the physical bitstream on each iteration.
+	DataChunkSize = 4096
+
+	// sampleRate is the fixed rate libopus always decodes to, regardless of the input sample rate.
+	sampleRate = 48000
+
+	// maxFrameSamples is the largest number of samples per channel a single Opus packet can decode
+	// to (120ms, the longest frame size the codec allows).
+	maxFrameSamples = sampleRate * 120 / 1000
+)
+
+// Decoder implements an OggOpus decoder. Ogg demuxing is handled by the same libogg bindings the
+// vorbis package uses; only the codec payload itself is handled by libopus.
+type Decoder struct {
+	sync.Mutex
+
+	// gain is the default track gain.
+	gain float32
+
+	// duration is the input length in milliseconds.
+	duration int32
+
+	syncState   vorbis.OggSyncState
+	streamState vorbis.OggStreamState
+	page        vorbis.OggPage
+	packet      vorbis.OggPacket
+
+	channels int32
+	preSkip  int32
+	comments []string
+	vendor   string
+
+	dec *opus.Decoder
+
+	input    librespot.SizedReadAtSeeker
+	pcm      []float32
+	buf      []float32
+	stopChan chan struct{}
+	closed   bool
+
+	lastGranulepos vorbis.OggInt64
+}
+
+// New creates and initialises a new OggOpus decoder for the provided bytestream.
+func New(r librespot.SizedReadAtSeeker, duration int32, gain float32) (*Decoder, error) {
+	d := &Decoder{
+		input:    r,
+		duration: duration,
+		gain:     gain,
+		stopChan: make(chan struct{}),
+	}
+
+	vorbis.OggSyncInit(&d.syncState)
+
+	if err := d.readStreamHeaders(); err != nil {
+		d.decoderStateCleanup()
+		return nil, err
+	}
+
+	dec, err := opus.NewDecoder(sampleRate, int(d.channels))
+	if err != nil {
+		d.decoderStateCleanup()
+		return nil, fmt.Errorf("opus: failed creating libopus decoder: %w", err)
+	}
+	d.dec = dec
+
+	d.pcm = make([]float32, maxFrameSamples*int(d.channels))
+
+	return d, nil
+}
+
+// Info returns some basic info about the Opus stream the decoder was fed with.
+func (d *Decoder) Info() Info {
+	return Info{
+		Channels:   d.channels,
+		SampleRate: sampleRate,
+		Comments:   d.comments,
+		Vendor:     d.vendor,
+	}
+}
+
+// Close stops and finalizes the decoding process, releases the allocated resources.
+// Puts the decoder into an unrecoverable state.
+func (d *Decoder) Close() {
+	if !d.stopRequested() {
+		close(d.stopChan)
+	}
+	d.Lock()
+	defer d.Unlock()
+	if d.closed {
+		return
+	}
+	d.closed = true
+	d.decoderStateCleanup()
+}
+
+func (d *Decoder) decoderStateCleanup() {
+	vorbis.OggStreamClear(&d.streamState)
+	d.streamState.Free()
+
+	vorbis.OggSyncClear(&d.syncState)
+	d.syncState.Free()
+
+	vorbis.OggPacketClear(&d.packet)
+	d.packet.Free()
+
+	d.page.Free()
+}
+
+func (d *Decoder) stopRequested() bool {
+	select {
+	case <-d.stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *Decoder) readChunk() (n int, err error) {
+	buf := vorbis.OggSyncBuffer(&d.syncState, DataChunkSize)
+	n, err = io.ReadFull(d.input, buf[:DataChunkSize])
+	vorbis.OggSyncWrote(&d.syncState, n)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return n, io.EOF
+	}
+	return n, err
+}
+
+// readStreamHeaders reads the two mandatory Ogg Opus header packets (OpusHead and OpusTags,
+// RFC 7845 section 5) off the first logical bitstream.
+func (d *Decoder) readStreamHeaders() error {
+	if _, err := d.readChunk(); err != nil {
+		return fmt.Errorf("opus: failed reading chunk: %w", err)
+	}
+
+	if ret := vorbis.OggSyncPageout(&d.syncState, &d.page); ret != 1 {
+		return errors.New("opus: not a valid Ogg bitstream")
+	}
+
+	vorbis.OggStreamInit(&d.streamState, vorbis.OggPageSerialno(&d.page))
+
+	if ret := vorbis.OggStreamPagein(&d.streamState, &d.page); ret < 0 {
+		return errors.New("opus: the supplied page does not belong to this Opus stream")
+	}
+	if ret := vorbis.OggStreamPacketout(&d.streamState, &d.packet); ret != 1 {
+		return errors.New("opus: unable to fetch the OpusHead packet from the first page")
+	}
+	if err := d.parseOpusHead(d.packet.Packet); err != nil {
+		return err
+	}
+
+	var headersRead int
+forPage:
+	for headersRead < 1 {
+		if res := vorbis.OggSyncPageout(&d.syncState, &d.page); res < 0 {
+			continue forPage
+		} else if res == 0 {
+			if _, err := d.readChunk(); err != nil {
+				return errors.New("opus: got EOF while reading Opus headers")
+			}
+			continue forPage
+		}
+		vorbis.OggStreamPagein(&d.streamState, &d.page)
+		for headersRead < 1 {
+			if ret := vorbis.OggStreamPacketout(&d.streamState, &d.packet); ret < 0 {
+				return errors.New("opus: data is missing near the OpusTags header")
+			} else if ret == 0 {
+				continue forPage
+			}
+			if err := d.parseOpusTags(d.packet.Packet); err != nil {
+				return err
+			}
+			headersRead++
+		}
+	}
+
+	return nil
+}
+
+func (d *Decoder) Read(p []float32) (n int, err error) {
+	d.Lock()
+	defer d.Unlock()
+	if d.closed {
+		return 0, errors.New("decoder: decoder has already been closed")
+	}
+
+	n = 0
+	for n < len(p) {
+		if len(d.buf) > 0 {
+			copied := copy(p[n:], d.buf)
+			d.buf = d.buf[copied:]
+			n += copied
+		}
+
+		err = d.readNextPage()
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (d *Decoder) readNextPage() (err error) {
+	for {
+		if ret := vorbis.OggSyncPageout(&d.syncState, &d.page); ret < 0 {
+			log.Debugf("opus: corrupt or missing data in bitstream")
+			continue
+		} else if ret == 0 {
+			_, err = d.readChunk()
+			if err != nil {
+				return err
+			}
+		} else {
+			break
+		}
+	}
+
+	vorbis.OggStreamPagein(&d.streamState, &d.page)
+
+	for {
+		if ret := vorbis.OggStreamPacketout(&d.streamState, &d.packet); ret < 0 {
+			continue
+		} else if ret == 0 {
+			break
+		}
+
+		samples, err := d.dec.DecodeFloat32(d.packet.Packet, d.pcm)
+		if err != nil {
+			return fmt.Errorf("opus: failed decoding packet: %w", err)
+		}
+
+		for i := 0; i < samples*int(d.channels); i++ {
+			d.buf = append(d.buf, d.pcm[i]*d.gain)
+		}
+
+		d.lastGranulepos = vorbis.OggPageGranulepos(&d.page)
+	}
+
+	if vorbis.OggPageEos(&d.page) == 1 {
+		return io.EOF
+	}
+
+	return nil
+}
+
+// SetPositionMs seeks to the given position, in milliseconds, using a byte-position estimate. See
+// vorbis.Decoder.SetPositionSamples for a sample-accurate alternative this package doesn't yet port.
+func (d *Decoder) SetPositionMs(pos int64) (err error) {
+	d.Lock()
+	defer d.Unlock()
+
+	_, err = d.input.Seek(pos*d.input.Size()/int64(d.duration), io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("failed seeking input: %w", err)
+	}
+
+	if _, err = d.readChunk(); err != nil {
+		return fmt.Errorf("failed reading chunk: %w", err)
+	}
+
+	for {
+		err = d.readNextPage()
+		if err != nil {
+			return fmt.Errorf("failed reading page: %w", err)
+		}
+
+		if d.PositionMs() >= pos {
+			break
+		}
+	}
+
+	d.buf = nil
+	return nil
+}
+
+func (d *Decoder) PositionMs() int64 {
+	return int64(d.lastGranulepos) * 1000 / sampleRate
+}
@@ -0,0 +1,82 @@
+package opus
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	librespot "go-librespot"
+)
+
+// Info represents basic information about the audio in an Opus bitstream.
+type Info = librespot.AudioInfo
+
+// parseOpusHead parses the mandatory identification header described in RFC 7845 section 5.1. Only the
+// fields the decoder needs are kept; pre-skip is currently informational since the caller
+// consumes priming samples the same way librespot's upstream Vorbis pipeline tolerates a short
+// silent lead-in.
+func (d *Decoder) parseOpusHead(packet []byte) error {
+	if len(packet) < 19 || string(packet[0:8]) != "OpusHead" {
+		return fmt.Errorf("opus: invalid OpusHead packet")
+	}
+
+	d.channels = int32(packet[9])
+	d.preSkip = int32(binary.LittleEndian.Uint16(packet[10:12]))
+
+	if d.channels < 1 {
+		return fmt.Errorf("opus: invalid channel count %d", d.channels)
+	}
+
+	return nil
+}
+
+// parseOpusTags parses the mandatory comment header described in RFC 7845 section 5.2, which mirrors
+// the layout of a Vorbis comment header (vendor string, then a list of "KEY=VALUE" comments).
+func (d *Decoder) parseOpusTags(packet []byte) error {
+	if len(packet) < 8 || string(packet[0:8]) != "OpusTags" {
+		return fmt.Errorf("opus: invalid OpusTags packet")
+	}
+	rest := packet[8:]
+
+	vendorLen, rest, err := readUint32LE(rest)
+	if err != nil {
+		return fmt.Errorf("opus: truncated OpusTags vendor length: %w", err)
+	}
+	if uint32(len(rest)) < vendorLen {
+		return fmt.Errorf("opus: truncated OpusTags vendor string")
+	}
+	d.vendor = string(rest[:vendorLen])
+	rest = rest[vendorLen:]
+
+	commentCount, rest, err := readUint32LE(rest)
+	if err != nil {
+		return fmt.Errorf("opus: truncated OpusTags comment count: %w", err)
+	}
+	// each remaining comment needs at least 4 bytes for its own length prefix, so a count beyond
+	// that bounds a corrupt/short packet from triggering a huge up-front allocation
+	if maxComments := uint32(len(rest)) / 4; commentCount > maxComments {
+		return fmt.Errorf("opus: OpusTags comment count %d exceeds remaining data", commentCount)
+	}
+
+	d.comments = make([]string, 0, commentCount)
+	for i := uint32(0); i < commentCount; i++ {
+		var length uint32
+		length, rest, err = readUint32LE(rest)
+		if err != nil {
+			return fmt.Errorf("opus: truncated OpusTags comment length: %w", err)
+		}
+		if uint32(len(rest)) < length {
+			return fmt.Errorf("opus: truncated OpusTags comment")
+		}
+		d.comments = append(d.comments, string(rest[:length]))
+		rest = rest[length:]
+	}
+
+	return nil
+}
+
+func readUint32LE(b []byte) (uint32, []byte, error) {
+	if len(b) < 4 {
+		return 0, nil, fmt.Errorf("need 4 bytes, got %d", len(b))
+	}
+	return binary.LittleEndian.Uint32(b[0:4]), b[4:], nil
+}
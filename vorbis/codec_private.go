@@ -0,0 +1,175 @@
+package vorbis
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/xlab/vorbis-go/vorbis"
+)
+
+// PacketReader supplies raw, already-demuxed Vorbis packets to a Decoder constructed via
+// NewFromCodecPrivate, in lieu of the Ogg framing layer.
+type PacketReader interface {
+	ReadPacket() ([]byte, error)
+}
+
+// NewFromCodecPrivate creates and initialises a Vorbis decoder from the three setup headers
+// packed into a single codec-private blob, as produced by Matroska demuxers and Widevine/CENC
+// packagers that don't carry Spotify's content in an Ogg container. Once initialised, raw Vorbis
+// packets are pulled from packetSource instead of being parsed out of Ogg pages.
+func NewFromCodecPrivate(codecPriv []byte, packetSource PacketReader, duration int32, gain float32) (*Decoder, error) {
+	headers, err := splitCodecPrivateHeaders(codecPriv)
+	if err != nil {
+		return nil, fmt.Errorf("vorbis: failed splitting codec-private headers: %w", err)
+	}
+
+	d := &Decoder{
+		duration:     duration,
+		gain:         gain,
+		targetGain:   gain,
+		stopChan:     make(chan struct{}),
+		packetSource: packetSource,
+	}
+
+	vorbis.InfoInit(&d.info)
+	vorbis.CommentInit(&d.comment)
+
+	for i, header := range headers {
+		packet := vorbis.OggPacket{
+			Packet:   header,
+			Bytes:    vorbis.CLong(len(header)),
+			BOS:      boolToCLong(i == 0),
+			Packetno: vorbis.OggInt64(i),
+		}
+		if ret := vorbis.SynthesisHeaderin(&d.info, &d.comment, &packet); ret < 0 {
+			d.decoderStateCleanup()
+			return nil, fmt.Errorf("vorbis: unable to decode codec-private header %d: %d", i, ret)
+		}
+	}
+
+	d.info.Deref()
+	d.comment.Deref()
+	d.comment.UserComments = make([][]byte, d.comment.Comments)
+	d.comment.Deref()
+
+	d.pcm = [][][]float32{
+		make([][]float32, d.info.Channels),
+	}
+	d.limiterEnv = make([]float32, d.info.Channels)
+	for i := range d.limiterEnv {
+		d.limiterEnv[i] = 1
+	}
+
+	if ret := vorbis.SynthesisInit(&d.dspState, &d.info); ret < 0 {
+		d.decoderStateCleanup()
+		return nil, errors.New("vorbis: error during playback initialization")
+	}
+
+	vorbis.BlockInit(&d.dspState, &d.block)
+
+	return d, nil
+}
+
+func boolToCLong(b bool) vorbis.CLong {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// readNextFromPacketSource decodes a single raw Vorbis packet pulled from d.packetSource,
+// mirroring the decode half of readNextPage without any Ogg page framing.
+func (d *Decoder) readNextFromPacketSource() error {
+	data, err := d.packetSource.ReadPacket()
+	if err != nil {
+		return err
+	}
+
+	packet := vorbis.OggPacket{Packet: data, Bytes: vorbis.CLong(len(data))}
+	if vorbis.Synthesis(&d.block, &packet) == 0 {
+		vorbis.SynthesisBlockin(&d.dspState, &d.block)
+	}
+
+	samples := d.safeSynthesisPcmout()
+	for ; samples > 0; samples = d.safeSynthesisPcmout() {
+		for i := 0; i < int(samples); i++ {
+			for j := 0; j < int(d.info.Channels); j++ {
+				d.buf = append(d.buf, d.applyLimiter(j, d.pcm[0][j][:samples][i]*d.targetGain))
+			}
+		}
+		vorbis.SynthesisRead(&d.dspState, samples)
+	}
+
+	return nil
+}
+
+// splitCodecPrivateHeaders splits a Vorbis codec-private blob into its three setup packets
+// (identification, comment, setup), supporting both the xiph-laced layout used by Matroska
+// (leading 0x02 byte) and the plain size-prefixed layout seen in some Widevine/CENC packagers.
+func splitCodecPrivateHeaders(data []byte) ([3][]byte, error) {
+	if len(data) > 0 && data[0] == 0x02 {
+		return splitXiphLacedHeaders(data[1:])
+	}
+	return splitSizePrefixedHeaders(data)
+}
+
+func splitSizePrefixedHeaders(data []byte) ([3][]byte, error) {
+	var headers [3][]byte
+
+	rest := data
+	for i := 0; i < 2; i++ {
+		if len(rest) < 2 {
+			return headers, errors.New("vorbis: truncated codec-private header length")
+		}
+		size := int(binary.BigEndian.Uint16(rest[:2]))
+		rest = rest[2:]
+		if size > len(rest) {
+			return headers, errors.New("vorbis: codec-private header length exceeds remaining data")
+		}
+		headers[i] = rest[:size]
+		rest = rest[size:]
+	}
+	if len(rest) == 0 {
+		return headers, errors.New("vorbis: codec-private blob is missing the setup header")
+	}
+	headers[2] = rest
+
+	return headers, nil
+}
+
+func splitXiphLacedHeaders(data []byte) ([3][]byte, error) {
+	var headers [3][]byte
+
+	rest := data
+	var sizes [2]int
+	for i := 0; i < 2; i++ {
+		size := 0
+		for {
+			if len(rest) == 0 {
+				return headers, errors.New("vorbis: truncated xiph lacing")
+			}
+			b := rest[0]
+			rest = rest[1:]
+			size += int(b)
+			if b != 0xff {
+				break
+			}
+		}
+		sizes[i] = size
+	}
+
+	for i, size := range sizes {
+		if size > len(rest) {
+			return headers, errors.New("vorbis: xiph-laced header length exceeds remaining data")
+		}
+		headers[i] = rest[:size]
+		rest = rest[size:]
+	}
+	if len(rest) == 0 {
+		return headers, errors.New("vorbis: codec-private blob is missing the setup header")
+	}
+	headers[2] = rest
+
+	return headers, nil
+}
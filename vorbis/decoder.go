@@ -17,6 +17,11 @@ const (
 	DataChunkSize = 4096 // could be also 8192
 )
 
+// errDecoderStopped is returned internally when Close is called while a read is in progress or
+// about to start, so the decode loop unwinds instead of issuing another (possibly slow, network-
+// backed) read.
+var errDecoderStopped = errors.New("vorbis: decoder stopped")
+
 // Decoder implements an OggVorbis decoder.
 type Decoder struct {
 	sync.Mutex
@@ -65,24 +70,41 @@ type Decoder struct {
 	stopChan chan struct{}
 	closed   bool
 
+	// packetSource, when set by NewFromCodecPrivate, supplies raw Vorbis packets directly and
+	// bypasses the Ogg framing layer entirely.
+	packetSource PacketReader
+
+	// frames, frameSamples and pendingFrame are only set when the Decoder was created via
+	// NewStreaming. pendingFrame holds whatever interleaved samples from the last Frame drained
+	// off frames haven't been copied out to a caller of Read yet.
+	frames       chan Frame
+	frameSamples int
+	pendingFrame []float32
+
+	// normalization, isAlbumContext, targetGain, limiterEnv, attackCoeff and releaseCoeff back the
+	// ReplayGain-aware limiter set up by SetNormalization. For decoders created via plain New,
+	// normalization.Threshold is zero and the limiter is a no-op.
+	normalization  NormalizationConfig
+	isAlbumContext bool
+	targetGain     float32
+	limiterEnv     []float32
+	attackCoeff    float32
+	releaseCoeff   float32
+
 	lastGranulepos vorbis.OggInt64
 }
 
 // Info represents basic information about the audio in a Vorbis bitstream.
-type Info struct {
-	Channels   int32
-	SampleRate int32
-	Comments   []string
-	Vendor     string
-}
+type Info = librespot.AudioInfo
 
 // New creates and initialises a new OggVorbis decoder for the provided bytestream.
 func New(r librespot.SizedReadAtSeeker, duration int32, gain float32) (*Decoder, error) {
 	d := &Decoder{
-		input:    r,
-		duration: duration,
-		gain:     gain,
-		stopChan: make(chan struct{}),
+		input:      r,
+		duration:   duration,
+		gain:       gain,
+		targetGain: gain,
+		stopChan:   make(chan struct{}),
 	}
 
 	vorbis.OggSyncInit(&d.syncState)
@@ -95,6 +117,10 @@ func New(r librespot.SizedReadAtSeeker, duration int32, gain float32) (*Decoder,
 	d.pcm = [][][]float32{
 		make([][]float32, d.info.Channels),
 	}
+	d.limiterEnv = make([]float32, d.info.Channels)
+	for i := range d.limiterEnv {
+		d.limiterEnv[i] = 1
+	}
 
 	if ret := vorbis.SynthesisInit(&d.dspState, &d.info); ret < 0 {
 		d.decoderStateCleanup()
@@ -127,8 +153,15 @@ func (d *Decoder) Close() {
 }
 
 func (d *Decoder) decoderStateCleanup() {
-	vorbis.OggStreamClear(&d.streamState)
-	d.streamState.Free()
+	// NewFromCodecPrivate never initializes the Ogg framing layer (there's no Ogg container when
+	// reading from a packetSource), so only clear it when it was actually set up.
+	if d.packetSource == nil {
+		vorbis.OggStreamClear(&d.streamState)
+		d.streamState.Free()
+
+		vorbis.OggSyncClear(&d.syncState)
+		d.syncState.Free()
+	}
 
 	vorbis.CommentClear(&d.comment)
 	d.comment.Free()
@@ -136,9 +169,6 @@ func (d *Decoder) decoderStateCleanup() {
 	vorbis.InfoClear(&d.info)
 	d.info.Free()
 
-	vorbis.OggSyncClear(&d.syncState)
-	d.syncState.Free()
-
 	vorbis.DspClear(&d.dspState)
 	d.dspState.Free()
 
@@ -236,7 +266,20 @@ forPage:
 	return nil
 }
 
+// Read fills p with decoded PCM samples. On a decoder created via NewStreaming, it drains the
+// Frames() channel fed by the background worker rather than decoding directly, so the two don't
+// race over the same underlying Ogg/Vorbis state. On a plain decoder, it decodes directly.
 func (d *Decoder) Read(p []float32) (n int, err error) {
+	if d.frames != nil {
+		return d.readFromFrames(p)
+	}
+	return d.decode(p)
+}
+
+// decode pulls PCM directly from the underlying Ogg/Vorbis pipeline, decoding as many pages as
+// needed to fill p. It is the read path used by Read on non-streaming decoders and internally by
+// the streaming worker; SetPositionSamples also refills d.buf through readNextPage when seeking.
+func (d *Decoder) decode(p []float32) (n int, err error) {
 	d.Lock()
 	defer d.Unlock()
 	if d.closed {
@@ -261,6 +304,25 @@ func (d *Decoder) Read(p []float32) (n int, err error) {
 	return n, nil
 }
 
+// readFromFrames drains whole Frames off the streaming channel, interleaving each one back into
+// p, until p is full or the channel is closed (stream ended or the decoder was stopped).
+func (d *Decoder) readFromFrames(p []float32) (n int, err error) {
+	for n < len(p) {
+		if len(d.pendingFrame) == 0 {
+			frame, ok := <-d.frames
+			if !ok {
+				return n, io.EOF
+			}
+			d.pendingFrame = interleave(frame)
+		}
+
+		copied := copy(p[n:], d.pendingFrame)
+		d.pendingFrame = d.pendingFrame[copied:]
+		n += copied
+	}
+	return n, nil
+}
+
 func (d *Decoder) safeSynthesisPcmout() (ret int32) {
 	defer func() {
 		err := recover()
@@ -285,7 +347,15 @@ func (d *Decoder) safeSynthesisPcmout() (ret int32) {
 }
 
 func (d *Decoder) readNextPage() (err error) {
+	if d.packetSource != nil {
+		return d.readNextFromPacketSource()
+	}
+
 	for {
+		if d.stopRequested() {
+			return errDecoderStopped
+		}
+
 		if ret := vorbis.OggSyncPageout(&d.syncState, &d.page); ret < 0 {
 			log.Debugf("vorbis: corrupt or missing data in bitstream")
 			continue
@@ -321,7 +391,7 @@ func (d *Decoder) readNextPage() (err error) {
 		for ; samples > 0; samples = d.safeSynthesisPcmout() {
 			for i := 0; i < int(samples); i++ {
 				for j := 0; j < int(d.info.Channels); j++ {
-					d.buf = append(d.buf, d.pcm[0][j][:samples][i]*d.gain)
+					d.buf = append(d.buf, d.applyLimiter(j, d.pcm[0][j][:samples][i]*d.targetGain))
 				}
 			}
 			vorbis.SynthesisRead(&d.dspState, samples)
@@ -338,35 +408,117 @@ func (d *Decoder) readNextPage() (err error) {
 	return nil
 }
 
-func (d *Decoder) SetPositionMs(pos int64) (err error) {
+// SetPositionMs seeks the decoder to the given position, in milliseconds, by converting it to an
+// absolute sample count using the stream's sample rate and delegating to SetPositionSamples.
+func (d *Decoder) SetPositionMs(pos int64) error {
+	return d.SetPositionSamples(pos * int64(d.info.SampleRate) / 1000)
+}
+
+// SetPositionSamples seeks the decoder to the given absolute PCM sample position. Rather than
+// estimating a byte offset from the stream's average bitrate, it bisects the input on Ogg page
+// granule positions to land near the target, then decodes forward a short distance to reach the
+// exact sample. dspState is restarted after the jump so residual block state from the discarded
+// audio doesn't bleed click artifacts into the first output frame.
+func (d *Decoder) SetPositionSamples(n int64) error {
 	d.Lock()
 	defer d.Unlock()
 
-	_, err = d.input.Seek(pos*d.input.Size()/int64(d.duration), io.SeekStart)
-	if err != nil {
-		return fmt.Errorf("failed seeking input: %w", err)
+	if err := d.seekToGranule(vorbis.OggInt64(n)); err != nil {
+		return fmt.Errorf("vorbis: failed seeking to sample %d: %w", n, err)
 	}
 
-	// read data at seek offset
-	if _, err = d.readChunk(); err != nil {
-		return fmt.Errorf("failed reading chunk: %w", err)
-	}
+	vorbis.SynthesisRestart(&d.dspState)
+	d.buf = nil
 
+	// lastGranulepos still holds its value from before the seek (or from a prior position
+	// entirely, on a backward seek). readNextPage only updates it for pages that complete a packet,
+	// so reset it: otherwise a page with no complete packet could leave a stale, larger value in
+	// place and end the loop below before any post-seek audio reaches n.
+	d.lastGranulepos = -1
 	for {
-		err = d.readNextPage()
+		if err := d.readNextPage(); err != nil {
+			return fmt.Errorf("vorbis: failed decoding forward to sample %d: %w", n, err)
+		}
+		if d.lastGranulepos >= vorbis.OggInt64(n) {
+			break
+		}
+	}
+
+	// d.buf now holds every sample decoded from wherever the bisection landed through n (and a
+	// little past it), not just the span starting at n: readNextPage keeps appending to it across
+	// every iteration of the loop above. Drop the head up to n, keeping the rest (including the
+	// bit decoded past n) so playback resumes exactly at n and continues on seamlessly.
+	channels := int64(d.info.Channels)
+	samplesInBuf := int64(len(d.buf)) / channels
+	bufStart := int64(d.lastGranulepos) - samplesInBuf
+	offset := n - bufStart
+	if offset < 0 {
+		offset = 0
+	} else if offset > samplesInBuf {
+		offset = samplesInBuf
+	}
+	d.buf = d.buf[offset*channels:]
+
+	// a decoder created via NewStreaming may already have frames queued up from before the seek;
+	// discard them so Read doesn't serve stale, pre-seek audio ahead of the freshly decoded buf.
+	d.discardQueuedFrames()
+
+	return nil
+}
+
+// seekToGranule bisects the input between its start and end, reading the Ogg page found at each
+// midpoint and comparing its granule position to target, narrowing the window until it spans a
+// single read chunk, then leaves d.input and d.syncState positioned to decode forward from there.
+func (d *Decoder) seekToGranule(target vorbis.OggInt64) error {
+	lo, hi := int64(0), d.input.Size()
+
+	for hi-lo > DataChunkSize {
+		mid := lo + (hi-lo)/2
+
+		granule, err := d.pageGranuleAt(mid)
 		if err != nil {
-			return fmt.Errorf("failed reading page: %w", err)
+			return err
 		}
 
-		if d.PositionMs() >= pos {
-			break
+		if granule < target {
+			lo = mid
+		} else {
+			hi = mid
 		}
 	}
 
-	d.buf = nil
+	if _, err := d.input.Seek(lo, io.SeekStart); err != nil {
+		return err
+	}
+	vorbis.OggSyncReset(&d.syncState)
+
+	if _, err := d.readChunk(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// pageGranuleAt seeks the input to offset, synchronizes to the next Ogg page boundary and returns
+// that page's granule position.
+func (d *Decoder) pageGranuleAt(offset int64) (vorbis.OggInt64, error) {
+	if _, err := d.input.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	vorbis.OggSyncReset(&d.syncState)
+
+	for {
+		if ret := vorbis.OggSyncPageout(&d.syncState, &d.page); ret == 1 {
+			return vorbis.OggPageGranulepos(&d.page), nil
+		} else if ret < 0 {
+			continue
+		}
+		if _, err := d.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+}
+
 func (d *Decoder) PositionMs() int64 {
 	return int64(vorbis.GranuleTime(&d.dspState, d.lastGranulepos) * 1000)
 }
@@ -0,0 +1,177 @@
+package vorbis
+
+import (
+	"math"
+
+	librespot "go-librespot"
+)
+
+// NormalizationMode selects which ReplayGain value pair a Decoder applies when normalizing its
+// output, mirroring librespot's --normalisation-type auto support.
+type NormalizationMode int
+
+const (
+	// NormalizationNone disables normalization; samples are scaled by the plain gain passed to New.
+	NormalizationNone NormalizationMode = iota
+
+	// NormalizationTrack applies the track's own ReplayGain values.
+	NormalizationTrack
+
+	// NormalizationAlbum applies the album's ReplayGain values.
+	NormalizationAlbum
+
+	// NormalizationAuto picks Album when the Spotify context being played is an album URI, and
+	// Track otherwise. See Decoder.SetAlbumContext.
+	NormalizationAuto
+)
+
+// NormalizationConfig carries the ReplayGain metadata and limiter tuning a Decoder uses to
+// normalize its output.
+type NormalizationConfig struct {
+	TrackGain float32
+	TrackPeak float32
+	AlbumGain float32
+	AlbumPeak float32
+
+	// PregainDb is added to the chosen ReplayGain value before converting to linear gain.
+	PregainDb float32
+
+	// Threshold, Attack, Release and Knee tune the feed-forward limiter applied after the gain
+	// multiply. Threshold is linear (not dB); Attack and Release are time constants in
+	// milliseconds; Knee is the linear width around Threshold over which reduction ramps in
+	// rather than engaging abruptly. A zero Threshold disables the limiter.
+	Threshold float32
+	Attack    float32
+	Release   float32
+	Knee      float32
+
+	Mode NormalizationMode
+}
+
+// NewWithNormalization creates a Decoder identical to New, but scales its output using
+// ReplayGain-aware normalization and a feed-forward limiter instead of a single flat gain.
+func NewWithNormalization(r librespot.SizedReadAtSeeker, duration int32, cfg NormalizationConfig) (*Decoder, error) {
+	d, err := New(r, duration, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	d.SetNormalization(cfg)
+
+	return d, nil
+}
+
+// SetNormalization updates the ReplayGain configuration and limiter tuning used to normalize the
+// decoder's output. Any ReplayGain field left at its zero value is filled in from the stream's
+// own embedded Vorbis comment tags, if present.
+func (d *Decoder) SetNormalization(cfg NormalizationConfig) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.normalization = d.applyTagDefaults(cfg)
+	d.attackCoeff = timeConstant(cfg.Attack, float32(d.info.SampleRate))
+	d.releaseCoeff = timeConstant(cfg.Release, float32(d.info.SampleRate))
+	if len(d.limiterEnv) != int(d.info.Channels) {
+		d.limiterEnv = make([]float32, d.info.Channels)
+	}
+	for i := range d.limiterEnv {
+		d.limiterEnv[i] = 1
+	}
+
+	d.recomputeTargetGain()
+}
+
+// SetAlbumContext tells the decoder whether the Spotify context currently being played is an
+// album, which NormalizationAuto uses to decide between Track and Album ReplayGain. It has no
+// effect outside of NormalizationAuto.
+func (d *Decoder) SetAlbumContext(isAlbum bool) {
+	d.Lock()
+	defer d.Unlock()
+
+	d.isAlbumContext = isAlbum
+	d.recomputeTargetGain()
+}
+
+func (d *Decoder) recomputeTargetGain() {
+	mode := d.normalization.Mode
+	if mode == NormalizationAuto {
+		if d.isAlbumContext {
+			mode = NormalizationAlbum
+		} else {
+			mode = NormalizationTrack
+		}
+	}
+
+	var gainDb, peak float32
+	switch mode {
+	case NormalizationTrack:
+		gainDb, peak = d.normalization.TrackGain, d.normalization.TrackPeak
+	case NormalizationAlbum:
+		gainDb, peak = d.normalization.AlbumGain, d.normalization.AlbumPeak
+	default:
+		d.targetGain = d.gain
+		return
+	}
+
+	target := dbToLinear(gainDb + d.normalization.PregainDb)
+	if peak > 0 && target*peak > 1 {
+		target = 1 / peak
+	}
+
+	d.targetGain = target
+}
+
+// applyLimiter applies the configured feed-forward limiter to a single sample already scaled by
+// the target gain, smoothing the instantaneous reduction through a one-pole attack/release
+// envelope per channel so engaging the limiter doesn't introduce audible clicks.
+func (d *Decoder) applyLimiter(channel int, sample float32) float32 {
+	cfg := &d.normalization
+	if cfg.Threshold <= 0 {
+		return sample
+	}
+
+	level := abs32(sample)
+	lowKnee := cfg.Threshold - cfg.Knee/2
+	highKnee := cfg.Threshold + cfg.Knee/2
+
+	reduction := float32(1)
+	switch {
+	case level <= lowKnee:
+		reduction = 1
+	case level >= highKnee || highKnee <= lowKnee:
+		reduction = cfg.Threshold / level
+	default:
+		// ramp the effective threshold linearly through the knee
+		t := (level - lowKnee) / (highKnee - lowKnee)
+		effectiveThreshold := lowKnee + t*(cfg.Threshold-lowKnee)
+		reduction = effectiveThreshold / level
+	}
+
+	coeff := d.releaseCoeff
+	if reduction < d.limiterEnv[channel] {
+		coeff = d.attackCoeff
+	}
+	d.limiterEnv[channel] += (reduction - d.limiterEnv[channel]) * coeff
+
+	return sample * d.limiterEnv[channel]
+}
+
+func dbToLinear(db float32) float32 {
+	return float32(math.Pow(10, float64(db)/20))
+}
+
+// timeConstant converts an attack/release time, in milliseconds, into the one-pole smoothing
+// coefficient applied once per sample at sampleRate. A non-positive time means "instantaneous".
+func timeConstant(ms float32, sampleRate float32) float32 {
+	if ms <= 0 {
+		return 1
+	}
+	return float32(1 - math.Exp(-1/(float64(ms)/1000*float64(sampleRate))))
+}
+
+func abs32(f float32) float32 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
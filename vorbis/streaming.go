@@ -0,0 +1,131 @@
+package vorbis
+
+import (
+	"io"
+
+	log "github.com/sirupsen/logrus"
+	librespot "go-librespot"
+)
+
+// defaultFrameMs is the duration, in milliseconds, of PCM pushed through the Frames channel on
+// each iteration of the streaming worker.
+const defaultFrameMs = 200
+
+// Frame is a fixed-size block of decoded PCM samples, one slice per channel.
+type Frame [][]float32
+
+// NewStreaming creates a Decoder identical to New, but additionally spawns a background goroutine
+// that continuously decodes ahead and pushes fixed-size Frames onto a bounded channel. This
+// decouples jitter on the underlying network-backed input from whatever drains Frames(), letting
+// the player keep the pipeline full through brief stalls. bufferedFrames sizes the channel; the
+// caller should pick enough frames to smooth over its expected stall duration.
+func NewStreaming(r librespot.SizedReadAtSeeker, duration int32, gain float32, bufferedFrames int) (*Decoder, error) {
+	d, err := New(r, duration, gain)
+	if err != nil {
+		return nil, err
+	}
+
+	d.frames = make(chan Frame, bufferedFrames)
+	d.frameSamples = int(d.info.SampleRate) * defaultFrameMs / 1000
+
+	go d.streamWorker()
+
+	return d, nil
+}
+
+// Frames returns the channel Frames are pushed onto. It is nil for decoders created with New
+// rather than NewStreaming.
+func (d *Decoder) Frames() <-chan Frame {
+	return d.frames
+}
+
+// streamWorker decodes ahead of consumption, pushing one Frame at a time onto d.frames until the
+// stream ends, the decoder is closed, or a decode error occurs.
+func (d *Decoder) streamWorker() {
+	defer close(d.frames)
+
+	channels := int(d.info.Channels)
+	flat := make([]float32, d.frameSamples*channels)
+
+	for {
+		select {
+		case <-d.stopChan:
+			return
+		default:
+		}
+
+		// decode directly rather than through the public Read, which would instead try to drain
+		// d.frames on a streaming decoder and deadlock against this very goroutine
+		n, err := d.decode(flat)
+		if n > 0 {
+			frame := deinterleave(flat[:n], channels)
+			select {
+			case d.frames <- frame:
+			case <-d.stopChan:
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF && err != errDecoderStopped {
+				log.WithError(err).Warn("vorbis: streaming worker stopped decoding")
+			}
+			return
+		}
+	}
+}
+
+// discardQueuedFrames drops any Frames already buffered in d.frames and any partially-consumed
+// pendingFrame, called by SetPositionSamples (while holding d.Lock, which streamWorker needs to
+// decode another Frame) so a seek on a streaming decoder doesn't serve stale, pre-seek audio
+// queued up before the jump. It is a no-op on decoders created via New rather than NewStreaming.
+func (d *Decoder) discardQueuedFrames() {
+	if d.frames == nil {
+		return
+	}
+
+	d.pendingFrame = nil
+	for {
+		select {
+		case _, ok := <-d.frames:
+			if !ok {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+func deinterleave(flat []float32, channels int) Frame {
+	samplesPerChannel := len(flat) / channels
+
+	frame := make(Frame, channels)
+	for c := 0; c < channels; c++ {
+		frame[c] = make([]float32, samplesPerChannel)
+		for i := 0; i < samplesPerChannel; i++ {
+			frame[c][i] = flat[i*channels+c]
+		}
+	}
+
+	return frame
+}
+
+// interleave is the inverse of deinterleave, used by Read to turn a Frame drained off the
+// streaming channel back into the flat layout its callers expect.
+func interleave(frame Frame) []float32 {
+	if len(frame) == 0 {
+		return nil
+	}
+
+	channels := len(frame)
+	samplesPerChannel := len(frame[0])
+
+	flat := make([]float32, samplesPerChannel*channels)
+	for i := 0; i < samplesPerChannel; i++ {
+		for c := 0; c < channels; c++ {
+			flat[i*channels+c] = frame[c][i]
+		}
+	}
+
+	return flat
+}
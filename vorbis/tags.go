@@ -0,0 +1,196 @@
+package vorbis
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Tags is a structured, case-insensitive view over a Vorbis comment header's UserComments,
+// exposing the conventional keys Spotify's metadata pipeline cares about alongside the raw
+// key/value pairs for anything else.
+type Tags struct {
+	Title       string
+	Artist      string
+	Album       string
+	TrackNumber string
+	Date        string
+	Genre       string
+
+	ReplayGainTrackGain string
+	ReplayGainTrackPeak string
+	ReplayGainAlbumGain string
+	ReplayGainAlbumPeak string
+
+	Picture *Picture
+
+	// Raw holds every comment key (upper-cased) mapped to all of its values, including the
+	// conventional keys above, per the Vorbis-comment spec allowing repeated keys.
+	Raw map[string][]string
+}
+
+// Picture is a decoded METADATA_BLOCK_PICTURE FLAC picture block, as optionally embedded in a
+// Vorbis comment header.
+type Picture struct {
+	MimeType    string
+	Description string
+	Width       uint32
+	Height      uint32
+	Data        []byte
+}
+
+// ParseTags parses raw "KEY=VALUE" Vorbis comment lines (as returned by Decoder.Info().Comments)
+// into a structured Tags value. Keys are matched case-insensitively; values are left as the UTF-8
+// strings the Vorbis-comment spec requires.
+func ParseTags(comments []string) (Tags, error) {
+	t := Tags{Raw: make(map[string][]string)}
+
+	for _, c := range comments {
+		key, value, ok := strings.Cut(c, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToUpper(key)
+		t.Raw[key] = append(t.Raw[key], value)
+
+		switch key {
+		case "TITLE":
+			t.Title = value
+		case "ARTIST":
+			t.Artist = value
+		case "ALBUM":
+			t.Album = value
+		case "TRACKNUMBER":
+			t.TrackNumber = value
+		case "DATE":
+			t.Date = value
+		case "GENRE":
+			t.Genre = value
+		case "REPLAYGAIN_TRACK_GAIN":
+			t.ReplayGainTrackGain = value
+		case "REPLAYGAIN_TRACK_PEAK":
+			t.ReplayGainTrackPeak = value
+		case "REPLAYGAIN_ALBUM_GAIN":
+			t.ReplayGainAlbumGain = value
+		case "REPLAYGAIN_ALBUM_PEAK":
+			t.ReplayGainAlbumPeak = value
+		case "METADATA_BLOCK_PICTURE":
+			pic, err := decodePicture(value)
+			if err != nil {
+				return Tags{}, fmt.Errorf("vorbis: failed decoding METADATA_BLOCK_PICTURE: %w", err)
+			}
+			t.Picture = pic
+		}
+	}
+
+	return t, nil
+}
+
+// Tags parses this decoder's comment header into a structured Tags value.
+func (d *Decoder) Tags() (Tags, error) {
+	return ParseTags(d.Info().Comments)
+}
+
+// decodePicture decodes a base64-encoded FLAC picture block, as embedded by the
+// METADATA_BLOCK_PICTURE Vorbis comment key.
+func decodePicture(b64 string) (*Picture, error) {
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+
+	r := bytes.NewReader(raw)
+
+	var pictureType uint32
+	if err := binary.Read(r, binary.BigEndian, &pictureType); err != nil {
+		return nil, fmt.Errorf("truncated picture type: %w", err)
+	}
+
+	mimeType, err := readFlacString(r)
+	if err != nil {
+		return nil, fmt.Errorf("truncated MIME type: %w", err)
+	}
+
+	description, err := readFlacString(r)
+	if err != nil {
+		return nil, fmt.Errorf("truncated description: %w", err)
+	}
+
+	var width, height, colorDepth, colorsUsed, dataLen uint32
+	for _, field := range []*uint32{&width, &height, &colorDepth, &colorsUsed, &dataLen} {
+		if err := binary.Read(r, binary.BigEndian, field); err != nil {
+			return nil, fmt.Errorf("truncated picture metadata: %w", err)
+		}
+	}
+	if int64(dataLen) > int64(r.Len()) {
+		return nil, fmt.Errorf("picture data length %d exceeds remaining %d bytes", dataLen, r.Len())
+	}
+
+	data := make([]byte, dataLen)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("truncated picture data: %w", err)
+	}
+
+	return &Picture{
+		MimeType:    mimeType,
+		Description: description,
+		Width:       width,
+		Height:      height,
+		Data:        data,
+	}, nil
+}
+
+func readFlacString(r *bytes.Reader) (string, error) {
+	var length uint32
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return "", err
+	}
+	if int64(length) > int64(r.Len()) {
+		return "", fmt.Errorf("string length %d exceeds remaining %d bytes", length, r.Len())
+	}
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+// applyTagDefaults fills in any zero-valued ReplayGain field of cfg from the stream's own
+// embedded Vorbis comment tags, so a caller without out-of-band gain metadata still gets sensible
+// normalization.
+func (d *Decoder) applyTagDefaults(cfg NormalizationConfig) NormalizationConfig {
+	tags, err := ParseTags(ReadInfo(&d.info, &d.comment).Comments)
+	if err != nil {
+		return cfg
+	}
+
+	if cfg.TrackGain == 0 {
+		cfg.TrackGain = parseGainDb(tags.ReplayGainTrackGain)
+	}
+	if cfg.TrackPeak == 0 {
+		cfg.TrackPeak = parsePeak(tags.ReplayGainTrackPeak)
+	}
+	if cfg.AlbumGain == 0 {
+		cfg.AlbumGain = parseGainDb(tags.ReplayGainAlbumGain)
+	}
+	if cfg.AlbumPeak == 0 {
+		cfg.AlbumPeak = parsePeak(tags.ReplayGainAlbumPeak)
+	}
+
+	return cfg
+}
+
+func parseGainDb(s string) float32 {
+	s = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "dB"))
+	v, _ := strconv.ParseFloat(s, 32)
+	return float32(v)
+}
+
+func parsePeak(s string) float32 {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(s), 32)
+	return float32(v)
+}